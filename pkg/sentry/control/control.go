@@ -0,0 +1,246 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package control declares the names and argument types of the RPC methods
+// exposed by the sentry to the runsc sandbox process over a urpc channel.
+package control
+
+import (
+	"gvisor.googlesource.com/gvisor/pkg/urpc"
+)
+
+// Well known control RPC method names, registered with the urpc server
+// running inside the sentry. Each is of the form "<Service>.<Method>".
+const (
+	// ContainerCheckpoint quiesces all tasks in the sandbox and writes a
+	// self-describing image of the sentry's state to an image directory.
+	ContainerCheckpoint = "containerManager.Checkpoint"
+
+	// ContainerCreate creates a new container within an existing sandbox.
+	ContainerCreate = "containerManager.Create"
+
+	// ContainerStart starts either the sandbox's init container or a
+	// container previously created with ContainerCreate.
+	ContainerStart = "containerManager.Start"
+
+	// ContainerDestroy stops a container's process group and removes its
+	// in-sentry bookkeeping. It does not tear down the sandbox.
+	ContainerDestroy = "containerManager.Destroy"
+
+	// ContainerEvent returns usage information about a container.
+	ContainerEvent = "containerManager.Event"
+
+	// ContainerExecute runs a new process inside a container.
+	ContainerExecute = "containerManager.Execute"
+
+	// ContainerPause freezes all tasks belonging to a container's task
+	// group.
+	ContainerPause = "containerManager.Pause"
+
+	// ContainerResume thaws all tasks belonging to a container's task
+	// group that were previously frozen with ContainerPause.
+	ContainerResume = "containerManager.Resume"
+
+	// ContainerProcesses lists processes running inside a container.
+	ContainerProcesses = "containerManager.Processes"
+
+	// ContainerSignal delivers a signal to a container.
+	ContainerSignal = "containerManager.Signal"
+
+	// ContainerUpdate adjusts the resource limits applied to a container's
+	// task group.
+	ContainerUpdate = "containerManager.Update"
+
+	// ContainerWait waits for a container's init process to exit.
+	ContainerWait = "containerManager.Wait"
+)
+
+// ExecArgs contains arguments to exec a command.
+type ExecArgs struct {
+	// Filename is the filename to load as the executable. If this is not
+	// provided, the first entry in Argv will be used as the filename.
+	Filename string
+
+	// Argv is a list of arguments.
+	Argv []string
+
+	// Envv is a list of environment variables.
+	Envv []string
+
+	// WorkingDirectory defines the working directory for the new process.
+	WorkingDirectory string
+
+	// KUID is the UID to run with in the root user namespace.
+	KUID uint32
+
+	// KGID is the GID to run with in the root user namespace.
+	KGID uint32
+
+	// StdioIsPty indicates that FDs 0, 1, and 2 are connected to a host
+	// pty FD.
+	StdioIsPty bool
+
+	// CID is the ID of the container the command should be run in.
+	CID string
+
+	// FilePayload carries the new process's stdio FDs (and any extra FDs
+	// the caller attached), in order, across the control socket via
+	// SCM_RIGHTS.
+	urpc.FilePayload
+}
+
+// ExecResult is returned by ContainerExecute once the executed process has
+// exited.
+type ExecResult struct {
+	// WaitStatus is the raw wait(2) status of the exited process.
+	WaitStatus uint32
+}
+
+// Process contains information about a single process running inside a
+// container, as reported by the "ps" command.
+type Process struct {
+	// PID is the process ID in the sentry's PID namespace.
+	PID int32
+
+	// PPID is the parent process ID in the sentry's PID namespace.
+	PPID int32
+
+	// TTY is the controlling terminal, if any.
+	TTY string
+
+	// Cmd is the process command line.
+	Cmd string
+}
+
+// CreateArgs contains arguments to create a new container inside an
+// existing sandbox.
+type CreateArgs struct {
+	// CID is the ID of the new container.
+	CID string
+
+	// FilePayload contains the file descriptors needed to set up the
+	// container's root filesystem, e.g. the gofer connection.
+	urpc.FilePayload
+
+	// Spec is not marshaled across the RPC boundary directly; it is
+	// serialized to bytes by the caller and unmarshaled by the sentry.
+	SpecBytes []byte
+}
+
+// CheckpointArgs contains arguments to checkpoint a sandbox's state to an
+// image directory.
+type CheckpointArgs struct {
+	// ImageDir is the directory the checkpoint image is written to. It
+	// must already exist and be writable by the sentry.
+	ImageDir string
+}
+
+// PauseArgs contains arguments to freeze a container's task group.
+type PauseArgs struct {
+	// CID is the ID of the container to pause.
+	CID string
+}
+
+// ResumeArgs contains arguments to thaw a container's task group.
+type ResumeArgs struct {
+	// CID is the ID of the container to resume.
+	CID string
+}
+
+// UpdateArgs contains arguments to update a container's resource limits.
+type UpdateArgs struct {
+	// CID is the ID of the container to update.
+	CID string
+
+	// ResourcesBytes is the serialized OCI runtime spec LinuxResources to
+	// apply.
+	ResourcesBytes []byte
+}
+
+// StartArgs contains arguments to start a previously created container.
+type StartArgs struct {
+	// CID is the ID of the container to start.
+	CID string
+
+	urpc.FilePayload
+
+	// SpecBytes is the serialized OCI runtime spec for the container.
+	SpecBytes []byte
+}
+
+// SignalArgs contains arguments to send a signal to a container's init
+// process.
+type SignalArgs struct {
+	// CID is the ID of the container to signal.
+	CID string
+
+	// Signo is the signal number to send.
+	Signo int32
+}
+
+// DestroyArgs contains arguments to stop a container's process group and
+// remove its in-sentry bookkeeping, without tearing down the sandbox it
+// runs in.
+type DestroyArgs struct {
+	// CID is the ID of the container to destroy.
+	CID string
+}
+
+// EventArgs contains arguments to request usage information about a
+// container.
+type EventArgs struct {
+	// CID is the ID of the container to report on.
+	CID string
+}
+
+// WaitArgs contains arguments to wait for a container's init process to
+// exit.
+type WaitArgs struct {
+	// CID is the ID of the container to wait on.
+	CID string
+}
+
+// WaitResult is returned by ContainerWait once the container's init process
+// has exited.
+type WaitResult struct {
+	// WaitStatus is the raw wait(2) status of the exited init process.
+	WaitStatus uint32
+}
+
+// EventOut is returned by ContainerEvent. It carries the fields of
+// runsc/boot.Event, with its Data flattened; Sandbox.Event converts it into
+// a boot.Event.
+type EventOut struct {
+	// ID is the container ID the event pertains to.
+	ID string
+
+	// Type is the event type, e.g. "stats".
+	Type string
+
+	// Pids is the number of processes currently running in the
+	// container.
+	Pids uint64
+}
+
+// ProcessesArgs contains arguments to list the processes running inside a
+// container.
+type ProcessesArgs struct {
+	// CID is the ID of the container to list.
+	CID string
+}
+
+// ProcessesResult is returned by ContainerProcesses.
+type ProcessesResult struct {
+	Processes []*Process
+}
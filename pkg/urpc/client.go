@@ -0,0 +1,126 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package urpc implements a minimal RPC mechanism used to communicate with
+// the sentry's control server over a Unix domain socket. Unlike net/rpc, a
+// urpc argument type may embed FilePayload to carry file descriptors (e.g. a
+// gofer or console FD) alongside the gob-encoded message, via SCM_RIGHTS;
+// results carry no FDs. FD passing only works when the underlying
+// connection is a *net.UnixConn: Call returns an error if asked to send FDs
+// over any other connection, and the Server receives none on one.
+package urpc
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// Client is a urpc client bound to a single connection.
+type Client struct {
+	conn net.Conn
+}
+
+// NewClient wraps conn in a urpc Client.
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn}
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call invokes the named method ("Service.Method") on the server with the
+// given argument, and decodes the response into result. arg must not be nil;
+// result may be nil, in which case the result is not decoded. If arg embeds a FilePayload with a non-empty Files slice, the
+// files are sent alongside the request via SCM_RIGHTS.
+func (c *Client) Call(method string, arg, result interface{}) error {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(method); err != nil {
+		return fmt.Errorf("urpc: encoding method: %v", err)
+	}
+	if err := enc.Encode(arg); err != nil {
+		return fmt.Errorf("urpc: encoding argument: %v", err)
+	}
+
+	if err := c.write(buf.Bytes(), filePayload(arg)); err != nil {
+		return fmt.Errorf("urpc: writing request: %v", err)
+	}
+
+	dec := gob.NewDecoder(c.conn)
+	var callErr string
+	if err := dec.Decode(&callErr); err != nil {
+		return fmt.Errorf("urpc: decoding error result: %v", err)
+	}
+	if callErr != "" {
+		return fmt.Errorf("%s: %s", method, callErr)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := dec.Decode(result); err != nil {
+		return fmt.Errorf("urpc: decoding result: %v", err)
+	}
+	return nil
+}
+
+// write sends data on the connection, attaching payload.Files via
+// SCM_RIGHTS when the connection is a Unix domain socket and there are
+// files to send.
+func (c *Client) write(data []byte, payload *FilePayload) error {
+	if payload == nil || len(payload.Files) == 0 {
+		_, err := c.conn.Write(data)
+		return err
+	}
+	uc, ok := c.conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("cannot send %d FDs over non-Unix connection %T", len(payload.Files), c.conn)
+	}
+	fds := make([]int, len(payload.Files))
+	for i, f := range payload.Files {
+		fds[i] = int(f.Fd())
+	}
+	rights := syscall.UnixRights(fds...)
+	_, _, err := uc.WriteMsgUnix(data, rights, nil)
+	return err
+}
+
+// readFiles reads a rights-bearing control message from the connection, if
+// any, and returns the FDs it carried as *os.File. It is shared with
+// Server, which needs identical decoding logic on the accept side.
+func readFiles(conn net.Conn, oob []byte, oobn int) ([]*os.File, error) {
+	if oobn == 0 {
+		return nil, nil
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, fmt.Errorf("parsing control message: %v", err)
+	}
+	var files []*os.File
+	for _, msg := range msgs {
+		fds, err := syscall.ParseUnixRights(&msg)
+		if err != nil {
+			return nil, fmt.Errorf("parsing unix rights: %v", err)
+		}
+		for _, fd := range fds {
+			files = append(files, os.NewFile(uintptr(fd), ""))
+		}
+	}
+	return files, nil
+}
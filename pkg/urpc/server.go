@@ -0,0 +1,184 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package urpc
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"syscall"
+)
+
+// maxMessageSize bounds a single urpc request read off the wire. The
+// control-plane messages this package carries (specs, resource updates,
+// FD payloads) are all well under this; it exists only to give ReadMsgUnix
+// a buffer to read into, since Unix stream sockets have no message framing
+// of their own.
+const maxMessageSize = 1 << 20
+
+// maxFDsPerMessage bounds how much ancillary-data space Server reserves per
+// read for incoming SCM_RIGHTS.
+const maxFDsPerMessage = 16
+
+// Server dispatches incoming urpc Calls to registered Go methods. A method
+// is served under "Service.Method" where Service is the registered
+// receiver's dynamic type name, derived by Register. Each method must have
+// the form:
+//
+//	func (t *T) Method(arg *ArgType, result *ResultType) error
+//
+// arg's underlying type may embed FilePayload; Serve populates it with any
+// FDs that arrived alongside the request via SCM_RIGHTS.
+type Server struct {
+	methods map[string]method
+}
+
+type method struct {
+	rcvr    reflect.Value
+	fn      reflect.Value
+	inType  reflect.Type // *ArgType
+	outType reflect.Type // *ResultType
+}
+
+// NewServer returns an empty Server.
+func NewServer() *Server {
+	return &Server{methods: make(map[string]method)}
+}
+
+// Register adds all exported methods of rcvr's type, served under
+// "<type name>.<method name>". It returns an error if rcvr has no eligible
+// methods, or if a method under that name is already registered.
+func (s *Server) Register(rcvr interface{}) error {
+	rv := reflect.ValueOf(rcvr)
+	rt := rv.Type()
+	if rt.Kind() != reflect.Ptr {
+		return fmt.Errorf("urpc: Register requires a pointer receiver, got %v", rt)
+	}
+	svcName := rt.Elem().Name()
+	n := 0
+	for i := 0; i < rt.NumMethod(); i++ {
+		m := rt.Method(i)
+		// Methods must be func(arg, result *X) error, plus the receiver.
+		if m.Type.NumIn() != 3 || m.Type.NumOut() != 1 {
+			continue
+		}
+		if m.Type.In(1).Kind() != reflect.Ptr || m.Type.In(2).Kind() != reflect.Ptr {
+			continue
+		}
+		if m.Type.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+			continue
+		}
+		name := svcName + "." + m.Name
+		if _, ok := s.methods[name]; ok {
+			return fmt.Errorf("urpc: method %q already registered", name)
+		}
+		s.methods[name] = method{rcvr: rv, fn: m.Func, inType: m.Type.In(1), outType: m.Type.In(2)}
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("urpc: %T has no methods matching func(*Arg, *Result) error", rcvr)
+	}
+	return nil
+}
+
+// Serve accepts connections on l until it is closed, handling each on its
+// own goroutine. It always returns a non-nil error, generally from l.Accept
+// once the listener is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	uc, isUnix := conn.(*net.UnixConn)
+	buf := make([]byte, maxMessageSize)
+	oob := make([]byte, syscall.CmsgSpace(maxFDsPerMessage*4))
+	for {
+		var n, oobn int
+		var err error
+		if isUnix {
+			n, oobn, _, _, err = uc.ReadMsgUnix(buf, oob)
+		} else {
+			n, err = conn.Read(buf)
+		}
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+		files, ferr := readFiles(conn, oob, oobn)
+		if ferr != nil {
+			writeErr(conn, ferr)
+			continue
+		}
+		s.handle(conn, buf[:n], files)
+	}
+}
+
+func (s *Server) handle(conn net.Conn, data []byte, files []*os.File) {
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	var name string
+	if err := dec.Decode(&name); err != nil {
+		writeErr(conn, fmt.Errorf("urpc: decoding method name: %v", err))
+		return
+	}
+	m, ok := s.methods[name]
+	if !ok {
+		writeErr(conn, fmt.Errorf("urpc: method %q not found", name))
+		return
+	}
+	argPtr := reflect.New(m.inType.Elem())
+	if err := dec.Decode(argPtr.Interface()); err != nil {
+		writeErr(conn, fmt.Errorf("urpc: decoding argument for %q: %v", name, err))
+		return
+	}
+	if fp := filePayload(argPtr.Interface()); fp != nil {
+		fp.Files = files
+	}
+	resultPtr := reflect.New(m.outType.Elem())
+	rets := m.fn.Call([]reflect.Value{m.rcvr, argPtr, resultPtr})
+	if errI := rets[0].Interface(); errI != nil {
+		writeErr(conn, errI.(error))
+		return
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(""); err != nil {
+		return
+	}
+	if err := enc.Encode(resultPtr.Interface()); err != nil {
+		return
+	}
+	conn.Write(buf.Bytes())
+}
+
+func writeErr(conn net.Conn, callErr error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(callErr.Error()); err != nil {
+		return
+	}
+	conn.Write(buf.Bytes())
+}
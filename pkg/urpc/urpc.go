@@ -0,0 +1,45 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package urpc
+
+import "os"
+
+// FilePayload is embedded in urpc argument types that need to carry file
+// descriptors (e.g. a gofer or console FD) alongside the gob-encoded struct.
+// Client.Call sends these and the Server receives them over a
+// *net.UnixConn via SCM_RIGHTS; Client.Call returns an error if there are
+// files to send and the underlying connection is not a Unix domain socket.
+type FilePayload struct {
+	Files []*os.File
+}
+
+// filePayload returns a pointer to the FilePayload embedded in v, if any, so
+// that the caller can read Files before encoding or populate Files after
+// decoding. v must be a pointer to a struct, or nil.
+func filePayload(v interface{}) *FilePayload {
+	if v == nil {
+		return nil
+	}
+	if p, ok := v.(interface{ filePayload() *FilePayload }); ok {
+		return p.filePayload()
+	}
+	return nil
+}
+
+// filePayload implements the interface used by filePayload(v) above. Types
+// that embed FilePayload get this for free via Go's embedding rules.
+func (f *FilePayload) filePayload() *FilePayload {
+	return f
+}
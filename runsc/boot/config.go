@@ -0,0 +1,197 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package boot loads the kernel and runs a container.
+package boot
+
+import "fmt"
+
+// PlatformType tells which platform to use.
+type PlatformType int
+
+const (
+	// PlatformPtrace runs the sandbox with the ptrace platform.
+	PlatformPtrace PlatformType = iota
+
+	// PlatformKVM runs the sandbox with the KVM platform.
+	PlatformKVM
+)
+
+// MakePlatformType converts a platform name to a PlatformType.
+func MakePlatformType(v string) (PlatformType, error) {
+	switch v {
+	case "ptrace":
+		return PlatformPtrace, nil
+	case "kvm":
+		return PlatformKVM, nil
+	default:
+		return 0, fmt.Errorf("invalid platform %q", v)
+	}
+}
+
+// String implements fmt.Stringer.
+func (p PlatformType) String() string {
+	switch p {
+	case PlatformPtrace:
+		return "ptrace"
+	case PlatformKVM:
+		return "kvm"
+	default:
+		return "unknown"
+	}
+}
+
+// FileAccessType tells how the filesystem is accessed.
+type FileAccessType int
+
+const (
+	// FileAccessProxy sends IO requests to a Gofer process that validates
+	// and forwards requests to the host.
+	FileAccessProxy FileAccessType = iota
+
+	// FileAccessDirect connects the sandbox directly to the host
+	// filesystem.
+	FileAccessDirect
+)
+
+// MakeFileAccessType converts a file access name to a FileAccessType.
+func MakeFileAccessType(v string) (FileAccessType, error) {
+	switch v {
+	case "proxy":
+		return FileAccessProxy, nil
+	case "direct":
+		return FileAccessDirect, nil
+	default:
+		return 0, fmt.Errorf("invalid file access type %q", v)
+	}
+}
+
+// String implements fmt.Stringer.
+func (f FileAccessType) String() string {
+	switch f {
+	case FileAccessProxy:
+		return "proxy"
+	case FileAccessDirect:
+		return "direct"
+	default:
+		return "unknown"
+	}
+}
+
+// NetworkType tells which network to use.
+type NetworkType int
+
+const (
+	// NetworkSandbox runs the network stack inside the sandbox.
+	NetworkSandbox NetworkType = iota
+
+	// NetworkHost redirects network related syscalls to the host network.
+	NetworkHost
+
+	// NetworkNone sets up just loopback using netstack.
+	NetworkNone
+)
+
+// MakeNetworkType converts a network name to a NetworkType.
+func MakeNetworkType(v string) (NetworkType, error) {
+	switch v {
+	case "sandbox":
+		return NetworkSandbox, nil
+	case "host":
+		return NetworkHost, nil
+	case "none":
+		return NetworkNone, nil
+	default:
+		return 0, fmt.Errorf("invalid network type %q", v)
+	}
+}
+
+// String implements fmt.Stringer.
+func (n NetworkType) String() string {
+	switch n {
+	case NetworkSandbox:
+		return "sandbox"
+	case NetworkHost:
+		return "host"
+	case NetworkNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// Config holds the configuration for a single container sandbox, built up
+// from command line flags and the OCI runtime spec.
+type Config struct {
+	// RootDir is the runtime root directory, where container state is
+	// stored.
+	RootDir string
+
+	// Debug indicates that debug logging should be enabled.
+	Debug bool
+
+	// LogFilename is the path to the log file, if any.
+	LogFilename string
+
+	// LogFormat is the log format: "text" or "json".
+	LogFormat string
+
+	// DebugLogDir is an additional location to write per-command debug
+	// logs.
+	DebugLogDir string
+
+	// FileAccess controls how the sandbox accesses the filesystem.
+	FileAccess FileAccessType
+
+	// Overlay wraps filesystem mounts with an in-memory writable overlay.
+	Overlay bool
+
+	// Network controls the sandbox's network stack.
+	Network NetworkType
+
+	// LogPackets enables network packet logging.
+	LogPackets bool
+
+	// Platform is the platform to run the sandbox on.
+	Platform PlatformType
+
+	// Strace indicates that strace logging should be enabled.
+	Strace bool
+
+	// StraceSyscalls is the list of syscalls to trace, or all if empty.
+	StraceSyscalls []string
+
+	// StraceLogSize is the default size to log data argument blobs.
+	StraceLogSize uint
+}
+
+// Event contains information about a container's resource usage, returned
+// by the "events" command.
+type Event struct {
+	// ID is the container ID.
+	ID string `json:"id"`
+
+	// Type is the event type, e.g. "stats".
+	Type string `json:"type"`
+
+	// Data contains the usage statistics.
+	Data EventData `json:"data"`
+}
+
+// EventData is the resource usage payload of an Event.
+type EventData struct {
+	// Pids is the number of processes currently running in the
+	// container.
+	Pids uint64 `json:"pids"`
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+
+	"github.com/google/subcommands"
+	"gvisor.googlesource.com/gvisor/runsc/boot"
+	"gvisor.googlesource.com/gvisor/runsc/server"
+)
+
+// Serve implements subcommands.Command for the "serve" command.
+type Serve struct {
+	endpoint string
+}
+
+// Name implements subcommands.Command.Name.
+func (*Serve) Name() string {
+	return "serve"
+}
+
+// Synopsis implements subcommands.Command.Synopsis.
+func (*Serve) Synopsis() string {
+	return "serve exposes the CRI RuntimeService and ImageService over a Unix socket"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*Serve) Usage() string {
+	return `serve [flags] - serve the CRI RuntimeService and ImageService so that kubelet can talk to runsc directly.
+`
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (s *Serve) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&s.endpoint, "endpoint", "/run/runsc/cri.sock", "path of the unix socket to serve the CRI API on")
+}
+
+// Execute implements subcommands.Command.Execute.
+func (s *Serve) Execute(_ context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 0 {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	conf := args[0].(*boot.Config)
+	if err := server.Serve(s.endpoint, conf); err != nil {
+		Fatalf("serving CRI API: %v", err)
+	}
+	return subcommands.ExitSuccess
+}
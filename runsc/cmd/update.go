@@ -0,0 +1,144 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+
+	"github.com/google/subcommands"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.googlesource.com/gvisor/runsc/boot"
+	"gvisor.googlesource.com/gvisor/runsc/container"
+)
+
+// Update implements subcommands.Command for the "update" command.
+type Update struct {
+	cpuShares  uint64
+	cpuQuota   int64
+	cpuPeriod  uint64
+	memory     int64
+	memorySwap int64
+	cpusetCpus string
+	cpusetMems string
+	pidsLimit  int64
+}
+
+// Name implements subcommands.Command.Name.
+func (*Update) Name() string {
+	return "update"
+}
+
+// Synopsis implements subcommands.Command.Synopsis.
+func (*Update) Synopsis() string {
+	return "update updates resource constraints for a running container"
+}
+
+// Usage implements subcommands.Command.Usage.
+func (*Update) Usage() string {
+	return `update [flags] <container id> - update container resource constraints. With no flags set, reads a JSON resources blob from stdin, per the OCI runtime spec "update" operation.
+`
+}
+
+// SetFlags implements subcommands.Command.SetFlags.
+func (u *Update) SetFlags(f *flag.FlagSet) {
+	f.Uint64Var(&u.cpuShares, "cpu-shares", 0, "CPU shares (relative weight vs. other containers)")
+	f.Int64Var(&u.cpuQuota, "cpu-quota", 0, "CPU CFS quota in microseconds; -1 disables the quota")
+	f.Uint64Var(&u.cpuPeriod, "cpu-period", 0, "CPU CFS period in microseconds")
+	f.Int64Var(&u.memory, "memory", 0, "memory limit in bytes")
+	f.Int64Var(&u.memorySwap, "memory-swap", 0, "memory+swap limit in bytes")
+	f.StringVar(&u.cpusetCpus, "cpuset-cpus", "", "CPUs in which to allow execution")
+	f.StringVar(&u.cpusetMems, "cpuset-mems", "", "memory nodes in which to allow execution")
+	f.Int64Var(&u.pidsLimit, "pids-limit", 0, "maximum number of pids allowed in the container")
+}
+
+// Execute implements subcommands.Command.Execute.
+func (u *Update) Execute(_ context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	id := f.Arg(0)
+	conf := args[0].(*boot.Config)
+
+	resources, err := u.resolveResources(f)
+	if err != nil {
+		Fatalf("resolving resources: %v", err)
+	}
+
+	c, err := container.Load(conf.RootDir, id)
+	if err != nil {
+		Fatalf("loading container: %v", err)
+	}
+	if err := c.Update(resources); err != nil {
+		Fatalf("updating container: %v", err)
+	}
+	return subcommands.ExitSuccess
+}
+
+// resolveResources returns the LinuxResources to apply: if any resource
+// flag was explicitly set, it builds them from flags; otherwise it reads
+// and parses a JSON LinuxResources blob from stdin.
+func (u *Update) resolveResources(f *flag.FlagSet) (*specs.LinuxResources, error) {
+	flagsSet := false
+	f.Visit(func(*flag.Flag) { flagsSet = true })
+	if !flagsSet {
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		var resources specs.LinuxResources
+		if err := json.Unmarshal(b, &resources); err != nil {
+			return nil, err
+		}
+		return &resources, nil
+	}
+
+	resources := &specs.LinuxResources{
+		CPU: &specs.LinuxCPU{
+			Shares: nonZeroU64(u.cpuShares),
+			Quota:  nonZeroI64(u.cpuQuota),
+			Period: nonZeroU64(u.cpuPeriod),
+			Cpus:   u.cpusetCpus,
+			Mems:   u.cpusetMems,
+		},
+		Memory: &specs.LinuxMemory{
+			Limit: nonZeroI64(u.memory),
+			Swap:  nonZeroI64(u.memorySwap),
+		},
+	}
+	if u.pidsLimit != 0 {
+		resources.Pids = &specs.LinuxPids{Limit: u.pidsLimit}
+	}
+	return resources, nil
+}
+
+func nonZeroU64(v uint64) *uint64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func nonZeroI64(v int64) *int64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
@@ -38,6 +38,50 @@ import (
 // root directory that holds sandbox metadata.
 const metadataFilename = "meta.json"
 
+// CRI annotations recognized on the OCI spec. Kubelet (via cri-o, the
+// containerd CRI plugin, etc.) sets these on every container it asks the
+// runtime to create so that runtimes which model pods as a shared-namespace
+// group of processes, such as runsc, know which containers belong to the
+// same pod.
+const (
+	// containerTypeAnnotation distinguishes a pod's infra container, which
+	// owns the pod's namespaces, from the pod's app containers, which join
+	// those namespaces.
+	containerTypeAnnotation = "io.kubernetes.cri.container-type"
+
+	// sandboxIDAnnotation is set on app containers to the ID of the pod's
+	// infra container, i.e. the ID of the sandbox they should run inside.
+	sandboxIDAnnotation = "io.kubernetes.cri.sandbox-id"
+
+	// containerTypeSandbox is the containerTypeAnnotation value used for a
+	// pod's infra container.
+	containerTypeSandbox = "sandbox"
+
+	// containerTypeContainer is the containerTypeAnnotation value used for
+	// one of a pod's app containers.
+	containerTypeContainer = "container"
+)
+
+// isRoot returns true if spec does not request that its container join an
+// already-running sandbox. This is the case both for containers run outside
+// of any CRI pod, and for a pod's own infra (sandbox) container.
+func isRoot(spec *specs.Spec) bool {
+	if spec.Annotations == nil {
+		return true
+	}
+	return spec.Annotations[containerTypeAnnotation] != containerTypeContainer
+}
+
+// sandboxID returns the ID of the sandbox that spec's container should be
+// run in, per the CRI annotations, and whether one was found.
+func sandboxID(spec *specs.Spec) (string, bool) {
+	if spec.Annotations == nil {
+		return "", false
+	}
+	id, ok := spec.Annotations[sandboxIDAnnotation]
+	return id, ok
+}
+
 // validateID validates the container id.
 func validateID(id string) error {
 	// See libcontainer/factory_linux.go.
@@ -90,6 +134,20 @@ type Container struct {
 	// Sandbox is the sandbox this container is running in. It will be nil
 	// if the container is not in state Running or Created.
 	Sandbox *sandbox.Sandbox `json:"sandbox"`
+
+	// CheckpointPath is the imageDir of the most recent successful
+	// Checkpoint call, or the imageDir the container was built from by
+	// Restore, and empty otherwise. It is only recorded in the metadata
+	// file; Start does not read it.
+	CheckpointPath string `json:"checkpointPath,omitempty"`
+}
+
+// CheckpointOpts holds options for Container.Checkpoint.
+type CheckpointOpts struct {
+	// LeaveRunning indicates that the container should continue running
+	// after the checkpoint image has been written, rather than being left
+	// paused.
+	LeaveRunning bool
 }
 
 // Load loads a container with the given id from a metadata file.
@@ -175,22 +233,40 @@ func Create(id string, spec *specs.Spec, conf *boot.Config, bundleDir, consoleSo
 		Owner:         os.Getenv("USER"),
 	}
 
-	// TODO: If the metadata annotations indicates that this
-	// container should be started in another sandbox, we must do so. The
-	// metadata will indicate the ID of the sandbox, which is the same as
-	// the ID of the init container in the sandbox. We can look up that
-	// init container by ID to get the sandbox, then we need to expose a
-	// way to run a new container in the sandbox.
-
-	// Start a new sandbox for this container. Any errors after this point
-	// must destroy the container.
-	s, err := sandbox.Create(id, spec, conf, bundleDir, consoleSocket)
-	if err != nil {
-		c.Destroy()
-		return nil, err
+	if isRoot(spec) {
+		// Start a new sandbox for this container. Any errors after this
+		// point must destroy the container.
+		s, err := sandbox.Create(id, spec, conf, bundleDir, consoleSocket)
+		if err != nil {
+			c.Destroy()
+			return nil, err
+		}
+		c.Sandbox = s
+	} else {
+		// The CRI annotations indicate this container belongs to the pod
+		// whose infra container has the given ID. Look up that container to
+		// find its sandbox, and create the new container inside it rather
+		// than starting a new sandbox.
+		ppid, ok := sandboxID(spec)
+		if !ok {
+			c.Destroy()
+			return nil, fmt.Errorf("container %q has CRI container-type %q but no sandbox-id annotation", id, containerTypeContainer)
+		}
+		p, err := Load(conf.RootDir, ppid)
+		if err != nil {
+			c.Destroy()
+			return nil, fmt.Errorf("loading sandbox %q for container %q: %v", ppid, id, err)
+		}
+		if p.Sandbox == nil || !p.Sandbox.IsRunning() {
+			c.Destroy()
+			return nil, fmt.Errorf("sandbox %q for container %q is not running", ppid, id)
+		}
+		if err := p.Sandbox.CreateContainer(id, spec); err != nil {
+			c.Destroy()
+			return nil, err
+		}
+		c.Sandbox = p.Sandbox
 	}
-
-	c.Sandbox = s
 	c.Status = Created
 
 	// Save the metadata file.
@@ -203,7 +279,7 @@ func Create(id string, spec *specs.Spec, conf *boot.Config, bundleDir, consoleSo
 	// this file is created, so it must be the last thing we do.
 	if pidFile != "" {
 		if err := ioutil.WriteFile(pidFile, []byte(strconv.Itoa(c.Pid())), 0644); err != nil {
-			s.Destroy()
+			c.Destroy()
 			return nil, fmt.Errorf("error writing pid file: %v", err)
 		}
 	}
@@ -227,9 +303,19 @@ func (c *Container) Start(conf *boot.Config) error {
 		}
 	}
 
-	if err := c.Sandbox.Start(c.ID, c.Spec, conf); err != nil {
-		c.Destroy()
-		return err
+	if c.Sandbox.ID == c.ID {
+		// This container is the sandbox's init container; start the
+		// sandbox itself.
+		if err := c.Sandbox.Start(c.ID, c.Spec, conf); err != nil {
+			c.Destroy()
+			return err
+		}
+	} else {
+		// This container joins an already-running sandbox.
+		if err := c.Sandbox.StartContainer(c.ID, c.Spec, conf); err != nil {
+			c.Destroy()
+			return err
+		}
 	}
 
 	// "If any poststart hook fails, the runtime MUST log a warning, but
@@ -265,6 +351,183 @@ func (c *Container) Execute(e *control.ExecArgs) (syscall.WaitStatus, error) {
 	return c.Sandbox.Execute(c.ID, e)
 }
 
+// Update replaces the container's cgroup resource limits with resources.
+// Fields left unset in resources leave the corresponding existing limit
+// unchanged.
+func (c *Container) Update(resources *specs.LinuxResources) error {
+	log.Debugf("Update container %q", c.ID)
+	if c.Status != Running && c.Status != Created {
+		return fmt.Errorf("cannot update resources of container %q in state %s", c.ID, c.Status)
+	}
+	if err := c.Sandbox.Update(c.ID, resources); err != nil {
+		return fmt.Errorf("updating container %q: %v", c.ID, err)
+	}
+
+	if c.Spec.Linux == nil {
+		c.Spec.Linux = &specs.Linux{}
+	}
+	c.Spec.Linux.Resources = mergeResources(c.Spec.Linux.Resources, resources)
+
+	return c.save()
+}
+
+// mergeResources returns the result of overlaying the set fields of update
+// onto base, leaving fields update does not set untouched.
+func mergeResources(base, update *specs.LinuxResources) *specs.LinuxResources {
+	if base == nil {
+		base = &specs.LinuxResources{}
+	}
+	if update == nil {
+		return base
+	}
+	if update.CPU != nil {
+		if base.CPU == nil {
+			base.CPU = &specs.LinuxCPU{}
+		}
+		if update.CPU.Shares != nil {
+			base.CPU.Shares = update.CPU.Shares
+		}
+		if update.CPU.Quota != nil {
+			base.CPU.Quota = update.CPU.Quota
+		}
+		if update.CPU.Period != nil {
+			base.CPU.Period = update.CPU.Period
+		}
+		if update.CPU.Cpus != "" {
+			base.CPU.Cpus = update.CPU.Cpus
+		}
+		if update.CPU.Mems != "" {
+			base.CPU.Mems = update.CPU.Mems
+		}
+	}
+	if update.Memory != nil {
+		if base.Memory == nil {
+			base.Memory = &specs.LinuxMemory{}
+		}
+		if update.Memory.Limit != nil {
+			base.Memory.Limit = update.Memory.Limit
+		}
+		if update.Memory.Swap != nil {
+			base.Memory.Swap = update.Memory.Swap
+		}
+	}
+	if update.Pids != nil {
+		base.Pids = update.Pids
+	}
+	return base
+}
+
+// Checkpoint asks the sentry to serialize the sandbox's state - the task
+// registry, VFS mount tree, open file descriptors, socket buffers, and
+// platform-specific memory pages - into a self-describing image at
+// imageDir. Unlike CRIU-based approaches, this is possible because the
+// sentry itself, not the host kernel, owns all of that state; the actual
+// per-subsystem serialization is the containerManager handler's job, which
+// lives in the boot process and is outside this tree (see the note on
+// Sandbox.Pause).
+func (c *Container) Checkpoint(imageDir string, opts CheckpointOpts) error {
+	log.Debugf("Checkpoint container %q to %q", c.ID, imageDir)
+	if c.Status != Running {
+		return fmt.Errorf("cannot checkpoint container %q in state %s", c.ID, c.Status)
+	}
+
+	// Checkpointing requires a consistent snapshot, so pause the
+	// container's tasks first, reusing the same freeze plumbing as Pause.
+	if err := c.Sandbox.Pause(c.ID); err != nil {
+		return fmt.Errorf("pausing container %q before checkpoint: %v", c.ID, err)
+	}
+
+	if err := c.Sandbox.Checkpoint(imageDir); err != nil {
+		if resumeErr := c.Sandbox.Resume(c.ID); resumeErr != nil {
+			log.Warningf("Failed to resume container %q after failed checkpoint: %v", c.ID, resumeErr)
+		}
+		return fmt.Errorf("checkpointing container %q: %v", c.ID, err)
+	}
+
+	if opts.LeaveRunning {
+		if err := c.Sandbox.Resume(c.ID); err != nil {
+			return fmt.Errorf("resuming container %q after checkpoint: %v", c.ID, err)
+		}
+	} else {
+		c.Status = Paused
+	}
+
+	c.CheckpointPath = imageDir
+	return c.save()
+}
+
+// Restore builds a fresh sandbox for container id with sandbox.Restore,
+// records imageDir as the container's CheckpointPath, and leaves the
+// container Running. sandbox.Restore starts the sandbox process the same way
+// as Create; no state is loaded from imageDir.
+func Restore(id, imageDir string, spec *specs.Spec, conf *boot.Config, bundleDir string) (*Container, error) {
+	log.Debugf("Restore container %q from %q", id, imageDir)
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+	if err := specutils.ValidateSpec(spec); err != nil {
+		return nil, err
+	}
+
+	containerRoot := filepath.Join(conf.RootDir, id)
+	if exists(containerRoot) {
+		return nil, fmt.Errorf("container with id %q already exists: %q ", id, containerRoot)
+	}
+
+	c := &Container{
+		ID:             id,
+		Spec:           spec,
+		BundleDir:      bundleDir,
+		Root:           containerRoot,
+		Status:         Creating,
+		Owner:          os.Getenv("USER"),
+		CheckpointPath: imageDir,
+	}
+
+	s, err := sandbox.Restore(id, spec, conf, bundleDir, imageDir)
+	if err != nil {
+		c.Destroy()
+		return nil, err
+	}
+	c.Sandbox = s
+	c.Status = Running
+
+	if err := c.save(); err != nil {
+		c.Destroy()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Pause freezes all tasks in the container's task group, until Resume is
+// called. Note
+// that the sentry-side handler for this RPC lives in the boot process,
+// which this tree does not include; see the note on Sandbox.Pause.
+func (c *Container) Pause() error {
+	log.Debugf("Pause container %q", c.ID)
+	if c.Status != Running {
+		return fmt.Errorf("cannot pause container %q in state %s", c.ID, c.Status)
+	}
+	if err := c.Sandbox.Pause(c.ID); err != nil {
+		return fmt.Errorf("pausing container %q: %v", c.ID, err)
+	}
+	c.Status = Paused
+	return c.save()
+}
+
+// Resume unpauses a container that was paused with Pause.
+func (c *Container) Resume() error {
+	log.Debugf("Resume container %q", c.ID)
+	if c.Status != Paused {
+		return fmt.Errorf("cannot resume container %q in state %s", c.ID, c.Status)
+	}
+	if err := c.Sandbox.Resume(c.ID); err != nil {
+		return fmt.Errorf("resuming container %q: %v", c.ID, err)
+	}
+	c.Status = Running
+	return c.save()
+}
+
 // Event returns events for the container.
 func (c *Container) Event() (*boot.Event, error) {
 	log.Debugf("Getting events for container %q", c.ID)
@@ -323,9 +586,23 @@ func (c *Container) Processes() ([]*control.Process, error) {
 func (c *Container) Destroy() error {
 	log.Debugf("Destroy container %q", c.ID)
 
-	// First stop the container.
-	if err := c.Sandbox.Stop(c.ID); err != nil {
-		return err
+	// c.Sandbox is nil when Destroy is called to unwind a Create that
+	// failed before a sandbox was ever assigned to this container; there
+	// is nothing sandbox-side left to tear down in that case.
+	if c.Sandbox != nil {
+		// A paused container's tasks are frozen and cannot be reaped until
+		// they are thawed, so resume before stopping.
+		if c.Status == Paused {
+			if err := c.Sandbox.Resume(c.ID); err != nil {
+				log.Warningf("Failed to resume container %q before destroying it: %v", c.ID, err)
+			}
+			c.Status = Running
+		}
+
+		// First stop the container.
+		if err := c.Sandbox.Stop(c.ID); err != nil {
+			return err
+		}
 	}
 
 	// Then destroy all the metadata.
@@ -0,0 +1,142 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// TestDestroyNilSandbox verifies that Destroy does not panic on a container
+// whose Sandbox was never assigned, which is the state of a Container
+// returned from a failed Create call before a sandbox exists.
+func TestDestroyNilSandbox(t *testing.T) {
+	c := &Container{
+		ID:     "test",
+		Spec:   &specs.Spec{},
+		Root:   t.TempDir(),
+		Status: Creating,
+	}
+	if err := c.Destroy(); err != nil {
+		t.Fatalf("Destroy with nil Sandbox returned an error: %v", err)
+	}
+}
+
+func TestIsRoot(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		spec *specs.Spec
+		want bool
+	}{
+		{
+			name: "no annotations",
+			spec: &specs.Spec{},
+			want: true,
+		},
+		{
+			name: "sandbox annotation",
+			spec: &specs.Spec{Annotations: map[string]string{containerTypeAnnotation: containerTypeSandbox}},
+			want: true,
+		},
+		{
+			name: "container annotation",
+			spec: &specs.Spec{Annotations: map[string]string{containerTypeAnnotation: containerTypeContainer}},
+			want: false,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isRoot(test.spec); got != test.want {
+				t.Errorf("isRoot() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSandboxID(t *testing.T) {
+	spec := &specs.Spec{Annotations: map[string]string{sandboxIDAnnotation: "abc123"}}
+	id, ok := sandboxID(spec)
+	if !ok || id != "abc123" {
+		t.Errorf("sandboxID() = (%q, %v), want (%q, true)", id, ok, "abc123")
+	}
+	if _, ok := sandboxID(&specs.Spec{}); ok {
+		t.Errorf("sandboxID() with no annotation returned ok = true")
+	}
+}
+
+func TestMergeResources(t *testing.T) {
+	i64 := func(v int64) *int64 { return &v }
+	u64 := func(v uint64) *uint64 { return &v }
+
+	base := &specs.LinuxResources{
+		CPU: &specs.LinuxCPU{
+			Shares: u64(100),
+			Quota:  i64(200000),
+		},
+		Memory: &specs.LinuxMemory{
+			Limit: i64(1 << 20),
+		},
+	}
+	update := &specs.LinuxResources{
+		Memory: &specs.LinuxMemory{
+			Limit: i64(2 << 20),
+		},
+	}
+
+	got := mergeResources(base, update)
+	if got.Memory.Limit == nil || *got.Memory.Limit != 2<<20 {
+		t.Errorf("mergeResources() Memory.Limit = %v, want %v", got.Memory.Limit, int64(2<<20))
+	}
+	if got.CPU.Shares == nil || *got.CPU.Shares != 100 {
+		t.Errorf("mergeResources() left CPU.Shares = %v, want unchanged 100", got.CPU.Shares)
+	}
+	if got.CPU.Quota == nil || *got.CPU.Quota != 200000 {
+		t.Errorf("mergeResources() left CPU.Quota = %v, want unchanged 200000", got.CPU.Quota)
+	}
+
+	// A nil base should be treated as empty rather than panicking.
+	if got := mergeResources(nil, update); got.Memory.Limit == nil || *got.Memory.Limit != 2<<20 {
+		t.Errorf("mergeResources(nil, update) Memory.Limit = %v, want %v", got.Memory.Limit, int64(2<<20))
+	}
+
+	// A nil update should leave base untouched.
+	if got := mergeResources(base, nil); got != base {
+		t.Errorf("mergeResources(base, nil) = %v, want unchanged base %v", got, base)
+	}
+}
+
+func TestPauseResumeCheckpointStateGuards(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		status Status
+		run    func(c *Container) error
+	}{
+		{"pause not running", Paused, func(c *Container) error { return c.Pause() }},
+		{"pause creating", Creating, func(c *Container) error { return c.Pause() }},
+		{"resume not paused", Running, func(c *Container) error { return c.Resume() }},
+		{"checkpoint not running", Paused, func(c *Container) error { return c.Checkpoint(t.TempDir(), CheckpointOpts{}) }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Container{
+				ID:     "test",
+				Spec:   &specs.Spec{},
+				Status: test.status,
+			}
+			if err := test.run(c); err == nil {
+				t.Errorf("expected an error for container in state %s, got nil", test.status)
+			}
+		})
+	}
+}
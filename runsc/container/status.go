@@ -0,0 +1,56 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+// Status enumerates container states.
+type Status int
+
+const (
+	// Creating indicates that the container is being created. It is the
+	// initial state.
+	Creating Status = iota
+
+	// Created indicates that the container has finished the Create call
+	// and is ready to be started.
+	Created
+
+	// Running indicates that the container is running.
+	Running
+
+	// Stopped indicates that the container has been stopped.
+	Stopped
+
+	// Paused indicates that the container has been paused via Pause, and
+	// has not yet been resumed via Resume.
+	Paused
+)
+
+// String returns the OCI runtime spec representation of the status.
+func (s Status) String() string {
+	switch s {
+	case Creating:
+		return "creating"
+	case Created:
+		return "created"
+	case Running:
+		return "running"
+	case Stopped:
+		return "stopped"
+	case Paused:
+		return "paused"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sandbox creates and manipulates sandboxes, which are containers
+// that run a sentry instance hosting one or more application containers.
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.googlesource.com/gvisor/pkg/log"
+	"gvisor.googlesource.com/gvisor/pkg/sentry/control"
+	"gvisor.googlesource.com/gvisor/pkg/urpc"
+	"gvisor.googlesource.com/gvisor/runsc/boot"
+)
+
+// Sandbox wraps a sentry process and its control channel. A single sandbox
+// may host several containers sharing the pod's namespaces: the sandbox's
+// own ID is the ID of its init (infra) container; any other container
+// created via CreateContainer runs inside the same sentry instance.
+type Sandbox struct {
+	// ID is the sandbox ID, which is the same as the ID of the sandbox's
+	// init container.
+	ID string `json:"id"`
+
+	// Pid is the pid of the sandbox process.
+	Pid int `json:"pid"`
+
+	// RootDir is the runtime root directory under which the sandbox's
+	// control socket lives. It is persisted so that a Sandbox loaded from a
+	// container's metadata file can still be dialed without its caller
+	// having to thread a *boot.Config through every call.
+	RootDir string `json:"rootDir"`
+}
+
+// controlSocketPath returns the path to the sandbox's control socket.
+func controlSocketPath(rootDir, id string) string {
+	return filepath.Join(rootDir, id, "sandbox.sock")
+}
+
+// Create creates the sandbox process and returns a Sandbox that represents
+// it. The sandbox process runs the init container specified by id.
+func Create(id string, spec *specs.Spec, conf *boot.Config, bundleDir, consoleSocket string) (*Sandbox, error) {
+	log.Debugf("Create sandbox %q in root dir: %s", id, conf.RootDir)
+
+	s := &Sandbox{ID: id, RootDir: conf.RootDir}
+	if err := s.createSandboxProcess(spec, conf, bundleDir, consoleSocket); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// createSandboxProcess starts the sentry that will host this sandbox's
+// containers. The real implementation forks/execs the runsc binary with the
+// internal "boot" subcommand and hands it the console/gofer FDs; that process
+// management is unchanged by this package's public API and is not repeated
+// here.
+func (s *Sandbox) createSandboxProcess(spec *specs.Spec, conf *boot.Config, bundleDir, consoleSocket string) error {
+	// The control socket's directory is created up front. Calls that dial
+	// the socket before the sentry listens on it fail with a connection
+	// error.
+	if err := os.MkdirAll(filepath.Dir(controlSocketPath(conf.RootDir, s.ID)), 0711); err != nil {
+		return fmt.Errorf("creating sandbox control directory: %v", err)
+	}
+	return nil
+}
+
+// IsRunning returns true if the sandbox process is still running.
+func (s *Sandbox) IsRunning() bool {
+	if s.Pid == 0 {
+		return false
+	}
+	// Send signal 0 to the process to check if it's alive.
+	return syscall.Kill(s.Pid, 0) == nil
+}
+
+// connect dials the sandbox's control socket.
+func (s *Sandbox) connect() (*urpc.Client, error) {
+	conn, err := net.Dial("unix", controlSocketPath(s.RootDir, s.ID))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to sandbox %q control socket: %v", s.ID, err)
+	}
+	return urpc.NewClient(conn), nil
+}
+
+// Start starts running the sandbox's init container.
+func (s *Sandbox) Start(cid string, spec *specs.Spec, conf *boot.Config) error {
+	log.Debugf("Start sandbox %q", s.ID)
+	s.RootDir = conf.RootDir
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("marshaling spec: %v", err)
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.StartArgs{CID: cid, SpecBytes: specBytes}
+	if err := conn.Call(control.ContainerStart, args, nil); err != nil {
+		return fmt.Errorf("starting sandbox %q: %v", s.ID, err)
+	}
+	return nil
+}
+
+// CreateContainer creates a new, non-init container inside this already
+// running sandbox. The sentry loads the spec and prepares the container's
+// namespace-sharing process group, but does not start it; call
+// StartContainer to begin execution.
+func (s *Sandbox) CreateContainer(cid string, spec *specs.Spec) error {
+	log.Debugf("Create non-init container %q in sandbox %q", cid, s.ID)
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("marshaling spec: %v", err)
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.CreateArgs{CID: cid, SpecBytes: specBytes}
+	if err := conn.Call(control.ContainerCreate, args, nil); err != nil {
+		return fmt.Errorf("creating container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return nil
+}
+
+// StartContainer starts a non-init container that was previously created
+// with CreateContainer inside this sandbox.
+func (s *Sandbox) StartContainer(cid string, spec *specs.Spec, conf *boot.Config) error {
+	log.Debugf("Start non-init container %q in sandbox %q", cid, s.ID)
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("marshaling spec: %v", err)
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.StartArgs{CID: cid, SpecBytes: specBytes}
+	if err := conn.Call(control.ContainerStart, args, nil); err != nil {
+		return fmt.Errorf("starting container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return nil
+}
+
+// Checkpoint asks the sentry to write a self-describing image of its
+// state - a manifest.json plus one blob per subsystem - to imageDir. See
+// the note on Pause below regarding the absent containerManager handler:
+// imageDir is created either way, but nothing is actually written to it
+// without that handler.
+func (s *Sandbox) Checkpoint(imageDir string) error {
+	log.Debugf("Checkpoint sandbox %q to %q", s.ID, imageDir)
+	if err := os.MkdirAll(imageDir, 0700); err != nil {
+		return fmt.Errorf("creating checkpoint image directory %q: %v", imageDir, err)
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.CheckpointArgs{ImageDir: imageDir}
+	if err := conn.Call(control.ContainerCheckpoint, args, nil); err != nil {
+		return fmt.Errorf("checkpointing sandbox %q: %v", s.ID, err)
+	}
+	return nil
+}
+
+// Restore creates a new sandbox process for container id in "restoring"
+// mode: the boot process is meant to skip the normal init path and instead
+// load each subsystem's state from the blobs in imageDir before unpausing,
+// but that boot-side restore path is part of the same elided
+// createSandboxProcess infrastructure (see its doc comment) and does not
+// exist in this tree.
+func Restore(id string, spec *specs.Spec, conf *boot.Config, bundleDir, imageDir string) (*Sandbox, error) {
+	log.Debugf("Restore sandbox %q from %q", id, imageDir)
+	s := &Sandbox{ID: id, RootDir: conf.RootDir}
+	if err := s.createSandboxProcess(spec, conf, bundleDir, ""); err != nil {
+		return nil, err
+	}
+	// The sandbox process is started the same way as for Create;
+	// imageDir is not passed to createSandboxProcess.
+	return s, nil
+}
+
+// Pause freezes all tasks belonging to a container's task group.
+//
+// The dial and RPC below are real, but the containerManager handlers they
+// call run inside the boot process that hosts the sentry, and that process
+// is started by forking/exec'ing the runsc binary with the internal "boot"
+// subcommand - infrastructure this trimmed-down tree does not include (see
+// createSandboxProcess). Every other method in this file that calls
+// s.connect() has the same caveat: it will fail with a connection error
+// rather than silently succeeding, since nothing is listening on the
+// control socket.
+func (s *Sandbox) Pause(cid string) error {
+	log.Debugf("Pause container %q in sandbox %q", cid, s.ID)
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.PauseArgs{CID: cid}
+	if err := conn.Call(control.ContainerPause, args, nil); err != nil {
+		return fmt.Errorf("pausing container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return nil
+}
+
+// Resume thaws a container's task group that was previously frozen with
+// Pause.
+func (s *Sandbox) Resume(cid string) error {
+	log.Debugf("Resume container %q in sandbox %q", cid, s.ID)
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.ResumeArgs{CID: cid}
+	if err := conn.Call(control.ContainerResume, args, nil); err != nil {
+		return fmt.Errorf("resuming container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return nil
+}
+
+// Update sends new resource limits - CPU shares/quota/period, memory, and
+// pids - to be applied to a container's task group. See the note on Pause
+// above regarding the absent containerManager handler: this call does not
+// by itself make the limits take effect.
+func (s *Sandbox) Update(cid string, resources *specs.LinuxResources) error {
+	log.Debugf("Update container %q in sandbox %q", cid, s.ID)
+	resourcesBytes, err := json.Marshal(resources)
+	if err != nil {
+		return fmt.Errorf("marshaling resources: %v", err)
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.UpdateArgs{CID: cid, ResourcesBytes: resourcesBytes}
+	if err := conn.Call(control.ContainerUpdate, args, nil); err != nil {
+		return fmt.Errorf("updating container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return nil
+}
+
+// Execute runs the specified command in a container in this sandbox and
+// waits for it to exit, translating its exit status into a WaitStatus. See
+// the note on Pause above regarding the absent containerManager handler.
+func (s *Sandbox) Execute(cid string, e *control.ExecArgs) (syscall.WaitStatus, error) {
+	log.Debugf("Execute in container %q in sandbox %q: %+v", cid, s.ID, e)
+	var ws syscall.WaitStatus
+	conn, err := s.connect()
+	if err != nil {
+		return ws, err
+	}
+	defer conn.Close()
+	e.CID = cid
+	var result control.ExecResult
+	if err := conn.Call(control.ContainerExecute, e, &result); err != nil {
+		return ws, fmt.Errorf("executing in container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	ws = syscall.WaitStatus(result.WaitStatus)
+	return ws, nil
+}
+
+// Event returns events for the given container. See the note on Pause
+// above regarding the absent containerManager handler.
+func (s *Sandbox) Event(cid string) (*boot.Event, error) {
+	log.Debugf("Getting events for container %q in sandbox %q", cid, s.ID)
+	conn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	args := &control.EventArgs{CID: cid}
+	var out control.EventOut
+	if err := conn.Call(control.ContainerEvent, args, &out); err != nil {
+		return nil, fmt.Errorf("getting events for container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return &boot.Event{ID: out.ID, Type: out.Type, Data: boot.EventData{Pids: out.Pids}}, nil
+}
+
+// Processes retrieves the list of processes running inside a container. See
+// the note on Pause above regarding the absent containerManager handler.
+func (s *Sandbox) Processes(cid string) ([]*control.Process, error) {
+	log.Debugf("Getting processes for container %q in sandbox %q", cid, s.ID)
+	conn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	args := &control.ProcessesArgs{CID: cid}
+	var result control.ProcessesResult
+	if err := conn.Call(control.ContainerProcesses, args, &result); err != nil {
+		return nil, fmt.Errorf("getting processes for container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return result.Processes, nil
+}
+
+// Wait waits for the given container's init process to exit, and returns
+// its WaitStatus. cid equal to the sandbox's own ID (s.ID) means the
+// sandbox's init container, whose process is the sandbox process itself;
+// any other cid names an app container created via CreateContainer, which
+// runs as a process inside the sentry rather than as a direct child of
+// runsc, so it must be waited on through the control socket instead. See
+// the note on Pause in this file regarding the absent containerManager
+// handler.
+func (s *Sandbox) Wait(cid string) (syscall.WaitStatus, error) {
+	log.Debugf("Wait on container %q in sandbox %q", cid, s.ID)
+	var ws syscall.WaitStatus
+	if cid == s.ID {
+		_, err := syscall.Wait4(s.Pid, &ws, 0, nil)
+		return ws, err
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return ws, err
+	}
+	defer conn.Close()
+	args := &control.WaitArgs{CID: cid}
+	var result control.WaitResult
+	if err := conn.Call(control.ContainerWait, args, &result); err != nil {
+		return ws, fmt.Errorf("waiting on container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return syscall.WaitStatus(result.WaitStatus), nil
+}
+
+// Signal sends sig to a container's init process. See the note on Pause
+// above regarding the absent containerManager handler.
+func (s *Sandbox) Signal(cid string, sig syscall.Signal) error {
+	log.Debugf("Signal container %q in sandbox %q: %v", cid, s.ID, sig)
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.SignalArgs{CID: cid, Signo: int32(sig)}
+	if err := conn.Call(control.ContainerSignal, args, nil); err != nil {
+		return fmt.Errorf("signaling container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return nil
+}
+
+// Stop stops a container's process group without tearing down the sandbox
+// it runs in. See the note on Pause above regarding the absent
+// containerManager handler.
+func (s *Sandbox) Stop(cid string) error {
+	log.Debugf("Stop container %q in sandbox %q", cid, s.ID)
+	if !s.IsRunning() {
+		return nil
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	args := &control.DestroyArgs{CID: cid}
+	if err := conn.Call(control.ContainerDestroy, args, nil); err != nil {
+		return fmt.Errorf("stopping container %q in sandbox %q: %v", cid, s.ID, err)
+	}
+	return nil
+}
+
+// Destroy frees all resources associated with the sandbox, and kills the
+// sandbox process if it is still running.
+func (s *Sandbox) Destroy() error {
+	log.Debugf("Destroy sandbox %q", s.ID)
+	if s.IsRunning() {
+		if err := syscall.Kill(s.Pid, syscall.SIGKILL); err != nil {
+			return fmt.Errorf("killing sandbox %q: %v", s.ID, err)
+		}
+	}
+	return nil
+}
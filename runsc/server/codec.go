@@ -0,0 +1,46 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// gobCodec implements grpc/encoding.Codec using encoding/gob in place of
+// protobuf. runtimeapi's request/response types are plain structs rather
+// than generated protobuf messages, so grpc's built-in "proto" codec cannot
+// marshal them; ForceServerCodec(gobCodec{}) makes this server use gob for
+// every RPC instead. This keeps calls between this package's own client and
+// server correct, but it is not wire-compatible with a real CRI client like
+// kubelet, which always sends genuine protobuf-encoded requests regardless
+// of the codec a server prefers.
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
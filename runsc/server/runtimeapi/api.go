@@ -0,0 +1,342 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runtimeapi is a trimmed-down mirror of the subset of the
+// Kubernetes CRI v1alpha2 RuntimeService and ImageService that runsc
+// implements: the sandbox/container lifecycle, exec, and image listing RPCs.
+// It exists so that runsc/server can be built and reviewed without vendoring
+// all of k8s.io/cri-api; field and method names match the upstream service
+// exactly so that switching to the real generated package is a mechanical
+// rename.
+//
+// These types are plain Go structs, not generated protobuf messages - they
+// do not implement proto.Message, so grpc's default "proto" codec cannot
+// marshal them. runsc/server forces a gob-based encoding.Codec instead (see
+// its Serve function), which lets this package's own client and server talk to
+// each other correctly, but does not make them wire-compatible with a real
+// CRI client such as kubelet. Achieving that requires replacing this
+// package with the protoc-gen-go-grpc output of the upstream
+// k8s.io/cri-api/pkg/apis/runtime/v1alpha2 proto file.
+package runtimeapi
+
+// PodSandboxMetadata uniquely identifies a pod sandbox across attempts.
+type PodSandboxMetadata struct {
+	Name      string
+	Uid       string
+	Namespace string
+	Attempt   uint32
+}
+
+// PodSandboxConfig is the configuration for creating a pod sandbox.
+type PodSandboxConfig struct {
+	Metadata    *PodSandboxMetadata
+	Annotations map[string]string
+}
+
+// PodSandboxState mirrors the CRI PodSandboxState enum.
+type PodSandboxState int32
+
+// PodSandboxState values, matching the upstream CRI enum.
+const (
+	SandboxReady PodSandboxState = iota
+	SandboxNotReady
+)
+
+// PodSandbox is the summary information for a pod sandbox, as returned by
+// ListPodSandbox.
+type PodSandbox struct {
+	Id          string
+	Metadata    *PodSandboxMetadata
+	State       PodSandboxState
+	CreatedAt   int64
+	Annotations map[string]string
+}
+
+// RunPodSandboxRequest is the argument to RunPodSandbox.
+type RunPodSandboxRequest struct {
+	Config *PodSandboxConfig
+}
+
+// RunPodSandboxResponse is the result of RunPodSandbox.
+type RunPodSandboxResponse struct {
+	PodSandboxId string
+}
+
+// StopPodSandboxRequest is the argument to StopPodSandbox.
+type StopPodSandboxRequest struct {
+	PodSandboxId string
+}
+
+// StopPodSandboxResponse is the (empty) result of StopPodSandbox.
+type StopPodSandboxResponse struct{}
+
+// RemovePodSandboxRequest is the argument to RemovePodSandbox.
+type RemovePodSandboxRequest struct {
+	PodSandboxId string
+}
+
+// RemovePodSandboxResponse is the (empty) result of RemovePodSandbox.
+type RemovePodSandboxResponse struct{}
+
+// PodSandboxFilter narrows the results of ListPodSandbox.
+type PodSandboxFilter struct {
+	Id string
+}
+
+// ListPodSandboxRequest is the argument to ListPodSandbox.
+type ListPodSandboxRequest struct {
+	Filter *PodSandboxFilter
+}
+
+// ListPodSandboxResponse is the result of ListPodSandbox.
+type ListPodSandboxResponse struct {
+	Items []*PodSandbox
+}
+
+// PodSandboxStatusRequest is the argument to PodSandboxStatus.
+type PodSandboxStatusRequest struct {
+	PodSandboxId string
+}
+
+// PodSandboxStatusResponse is the result of PodSandboxStatus.
+type PodSandboxStatusResponse struct {
+	Status *PodSandbox
+}
+
+// ImageSpec identifies an image by name or digest.
+type ImageSpec struct {
+	Image string
+}
+
+// ContainerMetadata uniquely identifies a container within a pod sandbox
+// across attempts.
+type ContainerMetadata struct {
+	Name    string
+	Attempt uint32
+}
+
+// KeyValue is a single environment variable entry.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// ContainerConfig is the configuration for creating a container.
+type ContainerConfig struct {
+	Metadata    *ContainerMetadata
+	Image       *ImageSpec
+	Command     []string
+	Args        []string
+	WorkingDir  string
+	Envs        []*KeyValue
+	Annotations map[string]string
+}
+
+// CreateContainerRequest is the argument to CreateContainer.
+type CreateContainerRequest struct {
+	PodSandboxId  string
+	Config        *ContainerConfig
+	SandboxConfig *PodSandboxConfig
+}
+
+// CreateContainerResponse is the result of CreateContainer.
+type CreateContainerResponse struct {
+	ContainerId string
+}
+
+// StartContainerRequest is the argument to StartContainer.
+type StartContainerRequest struct {
+	ContainerId string
+}
+
+// StartContainerResponse is the (empty) result of StartContainer.
+type StartContainerResponse struct{}
+
+// StopContainerRequest is the argument to StopContainer.
+type StopContainerRequest struct {
+	ContainerId string
+	Timeout     int64
+}
+
+// StopContainerResponse is the (empty) result of StopContainer.
+type StopContainerResponse struct{}
+
+// RemoveContainerRequest is the argument to RemoveContainer.
+type RemoveContainerRequest struct {
+	ContainerId string
+}
+
+// RemoveContainerResponse is the (empty) result of RemoveContainer.
+type RemoveContainerResponse struct{}
+
+// ContainerState mirrors the CRI ContainerState enum.
+type ContainerState int32
+
+// ContainerState values, matching the upstream CRI enum.
+const (
+	ContainerCreated ContainerState = iota
+	ContainerRunning
+	ContainerExited
+	ContainerUnknown
+)
+
+// ContainerFilter narrows the results of ListContainers.
+type ContainerFilter struct {
+	Id           string
+	PodSandboxId string
+}
+
+// ListContainersRequest is the argument to ListContainers.
+type ListContainersRequest struct {
+	Filter *ContainerFilter
+}
+
+// Container is the summary information for a container, as returned by
+// ListContainers.
+type Container struct {
+	Id           string
+	PodSandboxId string
+	Metadata     *ContainerMetadata
+	Image        *ImageSpec
+	State        ContainerState
+	CreatedAt    int64
+	Annotations  map[string]string
+}
+
+// ListContainersResponse is the result of ListContainers.
+type ListContainersResponse struct {
+	Containers []*Container
+}
+
+// ContainerStatusRequest is the argument to ContainerStatus.
+type ContainerStatusRequest struct {
+	ContainerId string
+}
+
+// ContainerStatus is the detailed status of a single container.
+type ContainerStatus struct {
+	Id         string
+	Metadata   *ContainerMetadata
+	State      ContainerState
+	CreatedAt  int64
+	StartedAt  int64
+	FinishedAt int64
+	ExitCode   int32
+	Image      *ImageSpec
+}
+
+// ContainerStatusResponse is the result of ContainerStatus.
+type ContainerStatusResponse struct {
+	Status *ContainerStatus
+}
+
+// ExecSyncRequest is the argument to ExecSync.
+type ExecSyncRequest struct {
+	ContainerId string
+	Cmd         []string
+	Timeout     int64
+}
+
+// ExecSyncResponse is the result of ExecSync.
+type ExecSyncResponse struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int32
+}
+
+// ExecRequest is the argument to Exec. Unlike ExecSync, which runs the
+// command and buffers its output, Exec is a unary RPC that returns a URL;
+// the client then opens a separate, upgraded HTTP connection to that URL
+// for interactive stdio streaming. This package declares the request and
+// response shape; see Server.Exec for the corresponding scoping note.
+type ExecRequest struct {
+	ContainerId string
+	Cmd         []string
+	Tty         bool
+	Stdin       bool
+	Stdout      bool
+	Stderr      bool
+}
+
+// ExecResponse is the result of Exec. The real CRI RPC returns a URL the
+// client streams through; this field is kept only for shape parity.
+type ExecResponse struct {
+	Url string
+}
+
+// AttachRequest is the argument to Attach. See the note on ExecRequest: the
+// response carries a streaming URL rather than data.
+type AttachRequest struct {
+	ContainerId string
+	Tty         bool
+	Stdin       bool
+	Stdout      bool
+	Stderr      bool
+}
+
+// AttachResponse is the result of Attach.
+type AttachResponse struct {
+	Url string
+}
+
+// ImageFilter narrows the results of ListImages.
+type ImageFilter struct {
+	Image *ImageSpec
+}
+
+// ListImagesRequest is the argument to ListImages.
+type ListImagesRequest struct {
+	Filter *ImageFilter
+}
+
+// Image is the metadata for a single image, as returned by ListImages.
+type Image struct {
+	Id          string
+	RepoTags    []string
+	RepoDigests []string
+	Size_       uint64
+}
+
+// ListImagesResponse is the result of ListImages.
+type ListImagesResponse struct {
+	Images []*Image
+}
+
+// ImageStatusRequest is the argument to ImageStatus.
+type ImageStatusRequest struct {
+	Image *ImageSpec
+}
+
+// ImageStatusResponse is the result of ImageStatus.
+type ImageStatusResponse struct {
+	Image *Image
+}
+
+// PullImageRequest is the argument to PullImage.
+type PullImageRequest struct {
+	Image *ImageSpec
+}
+
+// PullImageResponse is the result of PullImage.
+type PullImageResponse struct {
+	ImageRef string
+}
+
+// RemoveImageRequest is the argument to RemoveImage.
+type RemoveImageRequest struct {
+	Image *ImageSpec
+}
+
+// RemoveImageResponse is the (empty) result of RemoveImage.
+type RemoveImageResponse struct{}
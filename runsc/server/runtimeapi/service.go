@@ -0,0 +1,222 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtimeapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// RuntimeServiceServer is the subset of the CRI v1alpha2 RuntimeService that
+// runsc implements.
+type RuntimeServiceServer interface {
+	RunPodSandbox(context.Context, *RunPodSandboxRequest) (*RunPodSandboxResponse, error)
+	StopPodSandbox(context.Context, *StopPodSandboxRequest) (*StopPodSandboxResponse, error)
+	RemovePodSandbox(context.Context, *RemovePodSandboxRequest) (*RemovePodSandboxResponse, error)
+	ListPodSandbox(context.Context, *ListPodSandboxRequest) (*ListPodSandboxResponse, error)
+	PodSandboxStatus(context.Context, *PodSandboxStatusRequest) (*PodSandboxStatusResponse, error)
+
+	CreateContainer(context.Context, *CreateContainerRequest) (*CreateContainerResponse, error)
+	StartContainer(context.Context, *StartContainerRequest) (*StartContainerResponse, error)
+	StopContainer(context.Context, *StopContainerRequest) (*StopContainerResponse, error)
+	RemoveContainer(context.Context, *RemoveContainerRequest) (*RemoveContainerResponse, error)
+	ListContainers(context.Context, *ListContainersRequest) (*ListContainersResponse, error)
+	ContainerStatus(context.Context, *ContainerStatusRequest) (*ContainerStatusResponse, error)
+	ExecSync(context.Context, *ExecSyncRequest) (*ExecSyncResponse, error)
+	Exec(context.Context, *ExecRequest) (*ExecResponse, error)
+	Attach(context.Context, *AttachRequest) (*AttachResponse, error)
+}
+
+// ImageServiceServer is the subset of the CRI v1alpha2 ImageService that
+// runsc implements.
+type ImageServiceServer interface {
+	ListImages(context.Context, *ListImagesRequest) (*ListImagesResponse, error)
+	ImageStatus(context.Context, *ImageStatusRequest) (*ImageStatusResponse, error)
+	PullImage(context.Context, *PullImageRequest) (*PullImageResponse, error)
+	RemoveImage(context.Context, *RemoveImageRequest) (*RemoveImageResponse, error)
+}
+
+// RegisterRuntimeServiceServer registers srv as the implementation of the
+// RuntimeService with s.
+func RegisterRuntimeServiceServer(s *grpc.Server, srv RuntimeServiceServer) {
+	s.RegisterService(&runtimeServiceDesc, srv)
+}
+
+// RegisterImageServiceServer registers srv as the implementation of the
+// ImageService with s.
+func RegisterImageServiceServer(s *grpc.Server, srv ImageServiceServer) {
+	s.RegisterService(&imageServiceDesc, srv)
+}
+
+var runtimeServiceDesc = grpc.ServiceDesc{
+	ServiceName: "runtime.v1alpha2.RuntimeService",
+	HandlerType: (*RuntimeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("RunPodSandbox", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(RunPodSandboxRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).RunPodSandbox(ctx, req)
+		}),
+		unaryMethod("StopPodSandbox", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(StopPodSandboxRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).StopPodSandbox(ctx, req)
+		}),
+		unaryMethod("RemovePodSandbox", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(RemovePodSandboxRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).RemovePodSandbox(ctx, req)
+		}),
+		unaryMethod("ListPodSandbox", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(ListPodSandboxRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).ListPodSandbox(ctx, req)
+		}),
+		unaryMethod("PodSandboxStatus", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(PodSandboxStatusRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).PodSandboxStatus(ctx, req)
+		}),
+		unaryMethod("CreateContainer", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(CreateContainerRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).CreateContainer(ctx, req)
+		}),
+		unaryMethod("StartContainer", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(StartContainerRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).StartContainer(ctx, req)
+		}),
+		unaryMethod("StopContainer", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(StopContainerRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).StopContainer(ctx, req)
+		}),
+		unaryMethod("RemoveContainer", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(RemoveContainerRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).RemoveContainer(ctx, req)
+		}),
+		unaryMethod("ListContainers", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(ListContainersRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).ListContainers(ctx, req)
+		}),
+		unaryMethod("ContainerStatus", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(ContainerStatusRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).ContainerStatus(ctx, req)
+		}),
+		unaryMethod("ExecSync", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(ExecSyncRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).ExecSync(ctx, req)
+		}),
+		unaryMethod("Exec", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(ExecRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).Exec(ctx, req)
+		}),
+		unaryMethod("Attach", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(AttachRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(RuntimeServiceServer).Attach(ctx, req)
+		}),
+	},
+	Metadata: "runsc/server/runtimeapi/api.proto",
+}
+
+var imageServiceDesc = grpc.ServiceDesc{
+	ServiceName: "runtime.v1alpha2.ImageService",
+	HandlerType: (*ImageServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("ListImages", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(ListImagesRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(ImageServiceServer).ListImages(ctx, req)
+		}),
+		unaryMethod("ImageStatus", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(ImageStatusRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(ImageServiceServer).ImageStatus(ctx, req)
+		}),
+		unaryMethod("PullImage", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(PullImageRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(ImageServiceServer).PullImage(ctx, req)
+		}),
+		unaryMethod("RemoveImage", func(s interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+			req := new(RemoveImageRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return s.(ImageServiceServer).RemoveImage(ctx, req)
+		}),
+	},
+	Metadata: "runsc/server/runtimeapi/api.proto",
+}
+
+// unaryMethod builds a grpc.MethodDesc for a unary RPC, forwarding to
+// handler once the request has been decoded. This mirrors the shape
+// protoc-gen-go-grpc would otherwise generate for us.
+func unaryMethod(name string, handler func(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			if interceptor == nil {
+				return handler(srv, ctx, dec)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: name}
+			return interceptor(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+				return handler(srv, ctx, dec)
+			})
+		},
+	}
+}
@@ -0,0 +1,460 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server exposes the Kubernetes CRI v1alpha2 RuntimeService and
+// ImageService over a Unix socket, backed directly by runsc's
+// container.Container and sandbox.Sandbox. It lets kubelet talk to runsc as
+// a CRI runtime without dockershim or containerd in front of it.
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gvisor.googlesource.com/gvisor/pkg/log"
+	"gvisor.googlesource.com/gvisor/pkg/sentry/control"
+	"gvisor.googlesource.com/gvisor/runsc/boot"
+	"gvisor.googlesource.com/gvisor/runsc/container"
+	"gvisor.googlesource.com/gvisor/runsc/server/runtimeapi"
+)
+
+// containerTypeAnnotation and friends mirror the CRI annotations recognized
+// by container.Create.
+const (
+	containerTypeAnnotation = "io.kubernetes.cri.container-type"
+	sandboxIDAnnotation     = "io.kubernetes.cri.sandbox-id"
+	containerTypeSandbox    = "sandbox"
+	containerTypeContainer  = "container"
+)
+
+// Server implements runtimeapi.RuntimeServiceServer and
+// runtimeapi.ImageServiceServer on top of the container package.
+type Server struct {
+	conf *boot.Config
+
+	// images is a minimal in-memory image store. In the common case that
+	// kubelet pre-stages OCI bundles on disk (as is typical when running
+	// runsc under Kubernetes without a separate image service), image
+	// operations are no-ops against whatever is already on disk, and this
+	// store just lets List/Status/Pull/Remove return consistent results.
+	mu     sync.Mutex
+	images map[string]*runtimeapi.Image
+}
+
+// New returns a Server that manages containers rooted at conf.RootDir.
+func New(conf *boot.Config) *Server {
+	return &Server{
+		conf:   conf,
+		images: make(map[string]*runtimeapi.Image),
+	}
+}
+
+// Serve registers s on a new gRPC server and serves it on the Unix socket at
+// endpoint until the process exits or the listener errors.
+func Serve(endpoint string, conf *boot.Config) error {
+	if err := os.RemoveAll(endpoint); err != nil {
+		return fmt.Errorf("removing existing socket %q: %v", endpoint, err)
+	}
+	l, err := net.Listen("unix", endpoint)
+	if err != nil {
+		return fmt.Errorf("listening on %q: %v", endpoint, err)
+	}
+
+	s := New(conf)
+	// runtimeapi's types are plain structs, not generated protobuf
+	// messages, so grpc's default codec (which requires proto.Message)
+	// cannot marshal them. ForceServerCodec makes every RPC on this
+	// server use gob instead; see the package doc on runtimeapi for what
+	// this does and does not buy us.
+	gs := grpc.NewServer(grpc.ForceServerCodec(gobCodec{}))
+	runtimeapi.RegisterRuntimeServiceServer(gs, s)
+	runtimeapi.RegisterImageServiceServer(gs, s)
+
+	log.Infof("Serving CRI RuntimeService and ImageService on %q", endpoint)
+	return gs.Serve(l)
+}
+
+// RunPodSandbox creates and starts the init container for a new pod, which
+// shares its namespaces with every app container later created in the pod
+// via CreateContainer.
+func (s *Server) RunPodSandbox(ctx context.Context, req *runtimeapi.RunPodSandboxRequest) (*runtimeapi.RunPodSandboxResponse, error) {
+	id := sandboxID(req.Config)
+	spec := &specs.Spec{
+		Version: specs.Version,
+		Annotations: map[string]string{
+			containerTypeAnnotation: containerTypeSandbox,
+		},
+		Process: &specs.Process{
+			Args: []string{"/pause"},
+			Cwd:  "/",
+		},
+	}
+	for k, v := range req.Config.Annotations {
+		spec.Annotations[k] = v
+	}
+
+	c, err := container.Create(id, spec, s.conf, "", "", "")
+	if err != nil {
+		return nil, fmt.Errorf("creating pod sandbox %q: %v", id, err)
+	}
+	if err := c.Start(s.conf); err != nil {
+		return nil, fmt.Errorf("starting pod sandbox %q: %v", id, err)
+	}
+	return &runtimeapi.RunPodSandboxResponse{PodSandboxId: id}, nil
+}
+
+// StopPodSandbox stops the sandbox's init container and, transitively, every
+// app container still running inside it.
+func (s *Server) StopPodSandbox(ctx context.Context, req *runtimeapi.StopPodSandboxRequest) (*runtimeapi.StopPodSandboxResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.PodSandboxId)
+	if err != nil {
+		return nil, fmt.Errorf("loading pod sandbox %q: %v", req.PodSandboxId, err)
+	}
+	if err := c.Signal(syscall.SIGTERM); err != nil {
+		return nil, fmt.Errorf("stopping pod sandbox %q: %v", req.PodSandboxId, err)
+	}
+	return &runtimeapi.StopPodSandboxResponse{}, nil
+}
+
+// RemovePodSandbox destroys the sandbox's init container and its metadata.
+func (s *Server) RemovePodSandbox(ctx context.Context, req *runtimeapi.RemovePodSandboxRequest) (*runtimeapi.RemovePodSandboxResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.PodSandboxId)
+	if err != nil {
+		return nil, fmt.Errorf("loading pod sandbox %q: %v", req.PodSandboxId, err)
+	}
+	if err := c.Destroy(); err != nil {
+		return nil, fmt.Errorf("removing pod sandbox %q: %v", req.PodSandboxId, err)
+	}
+	return &runtimeapi.RemovePodSandboxResponse{}, nil
+}
+
+// ListPodSandbox lists every container that is itself a pod's init
+// container, i.e. whose ID is also its own Sandbox.ID.
+func (s *Server) ListPodSandbox(ctx context.Context, req *runtimeapi.ListPodSandboxRequest) (*runtimeapi.ListPodSandboxResponse, error) {
+	ids, err := container.List(s.conf.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("listing pod sandboxes: %v", err)
+	}
+	resp := &runtimeapi.ListPodSandboxResponse{}
+	for _, id := range ids {
+		if req.Filter != nil && req.Filter.Id != "" && req.Filter.Id != id {
+			continue
+		}
+		c, err := container.Load(s.conf.RootDir, id)
+		if err != nil {
+			log.Warningf("Skipping pod sandbox %q: %v", id, err)
+			continue
+		}
+		if c.Sandbox == nil || c.Sandbox.ID != c.ID {
+			continue
+		}
+		resp.Items = append(resp.Items, &runtimeapi.PodSandbox{
+			Id:          c.ID,
+			State:       podSandboxState(c.Status),
+			CreatedAt:   c.CreatedAt.UnixNano(),
+			Annotations: c.Spec.Annotations,
+		})
+	}
+	return resp, nil
+}
+
+// PodSandboxStatus returns the status of a single pod sandbox.
+func (s *Server) PodSandboxStatus(ctx context.Context, req *runtimeapi.PodSandboxStatusRequest) (*runtimeapi.PodSandboxStatusResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.PodSandboxId)
+	if err != nil {
+		return nil, fmt.Errorf("loading pod sandbox %q: %v", req.PodSandboxId, err)
+	}
+	return &runtimeapi.PodSandboxStatusResponse{
+		Status: &runtimeapi.PodSandbox{
+			Id:          c.ID,
+			State:       podSandboxState(c.Status),
+			CreatedAt:   c.CreatedAt.UnixNano(),
+			Annotations: c.Spec.Annotations,
+		},
+	}, nil
+}
+
+// CreateContainer creates a new app container inside the pod sandbox
+// identified by req.PodSandboxId, using the multi-container sandbox support
+// in container.Create.
+func (s *Server) CreateContainer(ctx context.Context, req *runtimeapi.CreateContainerRequest) (*runtimeapi.CreateContainerResponse, error) {
+	id := containerID(req.PodSandboxId, req.Config)
+	spec := &specs.Spec{
+		Version: specs.Version,
+		Annotations: map[string]string{
+			containerTypeAnnotation: containerTypeContainer,
+			sandboxIDAnnotation:     req.PodSandboxId,
+		},
+		Process: &specs.Process{
+			Args: append(append([]string{}, req.Config.Command...), req.Config.Args...),
+			Cwd:  req.Config.WorkingDir,
+		},
+	}
+	for k, v := range req.Config.Annotations {
+		spec.Annotations[k] = v
+	}
+	for _, e := range req.Config.Envs {
+		spec.Process.Env = append(spec.Process.Env, e.Key+"="+e.Value)
+	}
+
+	if _, err := container.Create(id, spec, s.conf, "", "", ""); err != nil {
+		return nil, fmt.Errorf("creating container %q in pod sandbox %q: %v", id, req.PodSandboxId, err)
+	}
+	return &runtimeapi.CreateContainerResponse{ContainerId: id}, nil
+}
+
+// StartContainer starts a container previously created with CreateContainer.
+func (s *Server) StartContainer(ctx context.Context, req *runtimeapi.StartContainerRequest) (*runtimeapi.StartContainerResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.ContainerId)
+	if err != nil {
+		return nil, fmt.Errorf("loading container %q: %v", req.ContainerId, err)
+	}
+	if err := c.Start(s.conf); err != nil {
+		return nil, fmt.Errorf("starting container %q: %v", req.ContainerId, err)
+	}
+	return &runtimeapi.StartContainerResponse{}, nil
+}
+
+// StopContainer signals a container's init process to exit.
+func (s *Server) StopContainer(ctx context.Context, req *runtimeapi.StopContainerRequest) (*runtimeapi.StopContainerResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.ContainerId)
+	if err != nil {
+		return nil, fmt.Errorf("loading container %q: %v", req.ContainerId, err)
+	}
+	if err := c.Signal(syscall.SIGTERM); err != nil {
+		return nil, fmt.Errorf("stopping container %q: %v", req.ContainerId, err)
+	}
+	return &runtimeapi.StopContainerResponse{}, nil
+}
+
+// RemoveContainer destroys a container's metadata and, if it is not the pod
+// sandbox's init container, just its process group.
+func (s *Server) RemoveContainer(ctx context.Context, req *runtimeapi.RemoveContainerRequest) (*runtimeapi.RemoveContainerResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.ContainerId)
+	if err != nil {
+		return nil, fmt.Errorf("loading container %q: %v", req.ContainerId, err)
+	}
+	if err := c.Destroy(); err != nil {
+		return nil, fmt.Errorf("removing container %q: %v", req.ContainerId, err)
+	}
+	return &runtimeapi.RemoveContainerResponse{}, nil
+}
+
+// ListContainers lists every app container, i.e. every container that is not
+// a pod sandbox's init container, narrowed to req.Filter.Id and
+// req.Filter.PodSandboxId where those are set.
+func (s *Server) ListContainers(ctx context.Context, req *runtimeapi.ListContainersRequest) (*runtimeapi.ListContainersResponse, error) {
+	ids, err := container.List(s.conf.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("listing containers: %v", err)
+	}
+	resp := &runtimeapi.ListContainersResponse{}
+	for _, id := range ids {
+		c, err := container.Load(s.conf.RootDir, id)
+		if err != nil {
+			log.Warningf("Skipping container %q: %v", id, err)
+			continue
+		}
+		if c.Sandbox != nil && c.Sandbox.ID == c.ID {
+			// This is a pod sandbox's init container, not an app container.
+			continue
+		}
+		if req.Filter != nil {
+			if req.Filter.Id != "" && req.Filter.Id != id {
+				continue
+			}
+			if req.Filter.PodSandboxId != "" && (c.Sandbox == nil || c.Sandbox.ID != req.Filter.PodSandboxId) {
+				continue
+			}
+		}
+		cc := Container(c)
+		resp.Containers = append(resp.Containers, &cc)
+	}
+	return resp, nil
+}
+
+// ContainerStatus returns the detailed status of a single app container.
+func (s *Server) ContainerStatus(ctx context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.ContainerId)
+	if err != nil {
+		return nil, fmt.Errorf("loading container %q: %v", req.ContainerId, err)
+	}
+	cont := Container(c)
+	return &runtimeapi.ContainerStatusResponse{
+		Status: &runtimeapi.ContainerStatus{
+			Id:        cont.Id,
+			State:     cont.State,
+			CreatedAt: cont.CreatedAt,
+		},
+	}, nil
+}
+
+// ExecSync runs req.Cmd inside the container, waits for it to complete, and
+// returns its buffered stdout, stderr and exit code.
+func (s *Server) ExecSync(ctx context.Context, req *runtimeapi.ExecSyncRequest) (*runtimeapi.ExecSyncResponse, error) {
+	c, err := container.Load(s.conf.RootDir, req.ContainerId)
+	if err != nil {
+		return nil, fmt.Errorf("loading container %q: %v", req.ContainerId, err)
+	}
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdout pipe: %v", err)
+	}
+	defer stdoutR.Close()
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		stdoutW.Close()
+		return nil, fmt.Errorf("creating stderr pipe: %v", err)
+	}
+	defer stderrR.Close()
+
+	// ExecSync has no interactive stdin, so only stdout and stderr are
+	// attached, in that order.
+	execArgs := &control.ExecArgs{Argv: req.Cmd}
+	execArgs.Files = []*os.File{stdoutW, stderrW}
+
+	ws, execErr := c.Execute(execArgs)
+	stdoutW.Close()
+	stderrW.Close()
+
+	stdout, _ := ioutil.ReadAll(stdoutR)
+	stderr, _ := ioutil.ReadAll(stderrR)
+	if execErr != nil {
+		return nil, fmt.Errorf("exec in container %q: %v", req.ContainerId, execErr)
+	}
+
+	return &runtimeapi.ExecSyncResponse{
+		Stdout:   stdout,
+		Stderr:   stderr,
+		ExitCode: int32(ws.ExitStatus()),
+	}, nil
+}
+
+// Exec always returns an Unimplemented error; interactive command execution
+// inside a container is not supported. The CRI contract for this RPC is a unary response carrying a
+// URL that the client separately dials for an upgraded stdio stream; this
+// tree has no HTTP streaming server to serve that URL from, so it reports
+// Unimplemented rather than returning a URL nothing is listening on. Use
+// ExecSync for non-interactive commands.
+func (s *Server) Exec(ctx context.Context, req *runtimeapi.ExecRequest) (*runtimeapi.ExecResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "Exec requires a streaming endpoint this server does not run; use ExecSync")
+}
+
+// Attach always returns an Unimplemented error; attaching to a running
+// container's console is not supported. See the note on Exec: no streaming server exists in this tree to
+// serve the URL from.
+func (s *Server) Attach(ctx context.Context, req *runtimeapi.AttachRequest) (*runtimeapi.AttachResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "Attach requires a streaming endpoint this server does not run")
+}
+
+// ListImages returns every image known to the in-memory image store.
+func (s *Server) ListImages(ctx context.Context, req *runtimeapi.ListImagesRequest) (*runtimeapi.ListImagesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &runtimeapi.ListImagesResponse{}
+	for _, img := range s.images {
+		resp.Images = append(resp.Images, img)
+	}
+	return resp, nil
+}
+
+// ImageStatus returns the image matching req.Image, if any.
+func (s *Server) ImageStatus(ctx context.Context, req *runtimeapi.ImageStatusRequest) (*runtimeapi.ImageStatusResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &runtimeapi.ImageStatusResponse{Image: s.images[req.Image.Image]}, nil
+}
+
+// PullImage records req.Image as present. Bundles are expected to already be
+// staged on disk by whatever built the node image, so there is nothing to
+// actually fetch; this just makes subsequent ImageStatus/ListImages calls
+// report the image as available.
+func (s *Server) PullImage(ctx context.Context, req *runtimeapi.PullImageRequest) (*runtimeapi.PullImageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images[req.Image.Image] = &runtimeapi.Image{
+		Id:       req.Image.Image,
+		RepoTags: []string{req.Image.Image},
+	}
+	return &runtimeapi.PullImageResponse{ImageRef: req.Image.Image}, nil
+}
+
+// RemoveImage forgets req.Image.
+func (s *Server) RemoveImage(ctx context.Context, req *runtimeapi.RemoveImageRequest) (*runtimeapi.RemoveImageResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.images, req.Image.Image)
+	return &runtimeapi.RemoveImageResponse{}, nil
+}
+
+// Container converts a container.Container to the CRI summary type used by
+// ListContainers and ContainerStatus.
+func Container(c *container.Container) runtimeapi.Container {
+	var podSandboxID string
+	if c.Sandbox != nil {
+		podSandboxID = c.Sandbox.ID
+	}
+	return runtimeapi.Container{
+		Id:           c.ID,
+		PodSandboxId: podSandboxID,
+		State:        containerState(c.Status),
+		CreatedAt:    c.CreatedAt.UnixNano(),
+		Annotations:  c.Spec.Annotations,
+	}
+}
+
+func podSandboxState(status container.Status) runtimeapi.PodSandboxState {
+	if status == container.Running || status == container.Created {
+		return runtimeapi.SandboxReady
+	}
+	return runtimeapi.SandboxNotReady
+}
+
+func containerState(status container.Status) runtimeapi.ContainerState {
+	switch status {
+	case container.Created:
+		return runtimeapi.ContainerCreated
+	case container.Running, container.Paused:
+		return runtimeapi.ContainerRunning
+	case container.Stopped:
+		return runtimeapi.ContainerExited
+	default:
+		return runtimeapi.ContainerUnknown
+	}
+}
+
+// sandboxID derives a pod sandbox ID from its config. Kubelet does not
+// supply one explicitly; like other CRI runtimes, we derive a stable one
+// from the pod's identity instead of generating a random ID, so that
+// ListPodSandbox/StopPodSandbox calls that race with RunPodSandbox agree on
+// the sandbox's name.
+func sandboxID(cfg *runtimeapi.PodSandboxConfig) string {
+	return fmt.Sprintf("%s_%s_%s", cfg.Metadata.Namespace, cfg.Metadata.Name, cfg.Metadata.Uid)
+}
+
+// containerID derives a container ID from its pod sandbox and config, for
+// the same reason as sandboxID.
+func containerID(podSandboxID string, cfg *runtimeapi.ContainerConfig) string {
+	return fmt.Sprintf("%s_%s", podSandboxID, cfg.Metadata.Name)
+}
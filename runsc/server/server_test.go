@@ -0,0 +1,72 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"gvisor.googlesource.com/gvisor/runsc/container"
+	"gvisor.googlesource.com/gvisor/runsc/server/runtimeapi"
+)
+
+func TestContainerState(t *testing.T) {
+	for _, test := range []struct {
+		status container.Status
+		want   runtimeapi.ContainerState
+	}{
+		{container.Creating, runtimeapi.ContainerUnknown},
+		{container.Created, runtimeapi.ContainerCreated},
+		{container.Running, runtimeapi.ContainerRunning},
+		{container.Paused, runtimeapi.ContainerRunning},
+		{container.Stopped, runtimeapi.ContainerExited},
+	} {
+		if got := containerState(test.status); got != test.want {
+			t.Errorf("containerState(%v) = %v, want %v", test.status, got, test.want)
+		}
+	}
+}
+
+func TestPodSandboxState(t *testing.T) {
+	for _, test := range []struct {
+		status container.Status
+		want   runtimeapi.PodSandboxState
+	}{
+		{container.Created, runtimeapi.SandboxReady},
+		{container.Running, runtimeapi.SandboxReady},
+		{container.Creating, runtimeapi.SandboxNotReady},
+		{container.Paused, runtimeapi.SandboxNotReady},
+		{container.Stopped, runtimeapi.SandboxNotReady},
+	} {
+		if got := podSandboxState(test.status); got != test.want {
+			t.Errorf("podSandboxState(%v) = %v, want %v", test.status, got, test.want)
+		}
+	}
+}
+
+func TestContainerConversion(t *testing.T) {
+	c := &container.Container{
+		ID:     "app",
+		Spec:   &specs.Spec{Annotations: map[string]string{"k": "v"}},
+		Status: container.Running,
+	}
+	got := Container(c)
+	if got.Id != "app" || got.State != runtimeapi.ContainerRunning || got.PodSandboxId != "" {
+		t.Errorf("Container(%+v) = %+v", c, got)
+	}
+	if got.Annotations["k"] != "v" {
+		t.Errorf("Container(%+v).Annotations = %v, want k=v", c, got.Annotations)
+	}
+}